@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+const (
+	keyPrefix        = "bnet:manifest:"
+	versionKey       = keyPrefix + "version"
+	defaultTTL       = 24 * time.Hour
+	defaultBatchSize = 500
+)
+
+/**
+* Store persists manifest entities behind a version swap so that a failed
+* refresh never clobbers the entries a previous successful run wrote.
+*/
+type Store interface {
+	// PutAll pipelines entries for a single definition type in batches,
+	// namespacing each key as bnet:manifest:<version>:<defType>:<hash>.
+	PutAll(ctx context.Context, version, defType string, entries map[string]string) error
+	// Get reads a single entity, using rueidis' client-side cache (DoCache)
+	// when enabled so downstream services get local read caching.
+	Get(ctx context.Context, version, defType, hash string) (string, error)
+	// CurrentVersion returns the manifest version currently live in the cache.
+	CurrentVersion(ctx context.Context) (string, error)
+	// Ping checks Redis connectivity, for use by health checks.
+	Ping(ctx context.Context) error
+	// SwapVersion points bnet:manifest:version at version and deletes the
+	// previous version's keys via SCAN, so a run that fails mid-write leaves
+	// the prior version fully intact.
+	SwapVersion(ctx context.Context, version string) error
+	Close()
+}
+
+// Options configures a redisStore.
+type Options struct {
+	Addrs []string
+	// TTL applied to every entity written via PutAll. Defaults to 24h.
+	TTL time.Duration
+	// BatchSize caps how many SETEX calls are pipelined per MSET-style round trip.
+	BatchSize int
+	// ClientSideCache opts into rueidis' local read cache for Get.
+	ClientSideCache bool
+	// CacheTTL bounds how long a client-side cached read may be served locally.
+	CacheTTL time.Duration
+}
+
+type redisStore struct {
+	client    rueidis.Client
+	ttl       time.Duration
+	batchSize int
+	cacheTTL  time.Duration
+}
+
+/**
+* Dials a rueidis client for the given options, applying the same defaults
+* the scrapper has always used (24h TTL, 500-key batches).
+*/
+func NewRedisStore(opts Options) (Store, error) {
+	if opts.TTL <= 0 {
+		opts.TTL = defaultTTL
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+
+	client, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  opts.Addrs,
+		DisableCache: !opts.ClientSideCache,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &redisStore{
+		client:    client,
+		ttl:       opts.TTL,
+		batchSize: opts.BatchSize,
+		cacheTTL:  opts.CacheTTL,
+	}, nil
+}
+
+func namespacedKey(version, defType, hash string) string {
+	return fmt.Sprintf("%s%s:%s:%s", keyPrefix, version, defType, hash)
+}
+
+/**
+* PutAll pipelines SETEX writes for every entry in batches of batchSize so a
+* definition with tens of thousands of hashes costs a handful of round trips
+* instead of one per key.
+*/
+func (s *redisStore) PutAll(ctx context.Context, version, defType string, entries map[string]string) error {
+	hashes := make([]string, 0, len(entries))
+	for hash := range entries {
+		hashes = append(hashes, hash)
+	}
+
+	ttlSeconds := int64(s.ttl.Seconds())
+	for i := 0; i < len(hashes); i += s.batchSize {
+		end := i + s.batchSize
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+
+		batch := hashes[i:end]
+		cmds := make(rueidis.Commands, 0, len(batch))
+		for _, hash := range batch {
+			cmds = append(cmds, s.client.B().Setex().
+				Key(namespacedKey(version, defType, hash)).
+				Seconds(ttlSeconds).
+				Value(entries[hash]).
+				Build())
+		}
+
+		for _, resp := range s.client.DoMulti(ctx, cmds...) {
+			if err := resp.Error(); err != nil {
+				return fmt.Errorf("failed to pipeline write batch for %s: %w", defType, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *redisStore) Get(ctx context.Context, version, defType, hash string) (string, error) {
+	cmd := s.client.B().Get().Key(namespacedKey(version, defType, hash)).Cache()
+	val, err := s.client.DoCache(ctx, cmd, s.cacheTTL).ToString()
+	if err != nil {
+		if rueidis.IsRedisNil(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read %s/%s: %w", defType, hash, err)
+	}
+	return val, nil
+}
+
+func (s *redisStore) CurrentVersion(ctx context.Context) (string, error) {
+	val, err := s.client.Do(ctx, s.client.B().Get().Key(versionKey).Build()).ToString()
+	if err != nil {
+		if rueidis.IsRedisNil(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read current manifest version: %w", err)
+	}
+	return val, nil
+}
+
+/**
+* SwapVersion writes the new version pointer and then deletes the previous
+* version's keys by SCANning its prefix, rather than FlushAll-ing the whole
+* cache up front. A crash before this point leaves the last good version
+* fully readable.
+*/
+func (s *redisStore) SwapVersion(ctx context.Context, version string) error {
+	prev, err := s.CurrentVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.client.Do(ctx, s.client.B().Set().Key(versionKey).Value(version).Build()).Error(); err != nil {
+		return fmt.Errorf("failed to write manifest version: %w", err)
+	}
+
+	if prev == "" || prev == version {
+		return nil
+	}
+
+	return s.deletePrefix(ctx, prev)
+}
+
+func (s *redisStore) deletePrefix(ctx context.Context, version string) error {
+	prefix := fmt.Sprintf("%s%s:", keyPrefix, version)
+
+	var cursor uint64
+	for {
+		entry, err := s.client.Do(ctx, s.client.B().Scan().Cursor(cursor).Match(prefix+"*").Count(1000).Build()).AsScanEntry()
+		if err != nil {
+			return fmt.Errorf("failed to scan stale keys for version %s: %w", version, err)
+		}
+
+		if len(entry.Elements) > 0 {
+			if err := s.client.Do(ctx, s.client.B().Del().Key(entry.Elements...).Build()).Error(); err != nil {
+				return fmt.Errorf("failed to delete stale keys for version %s: %w", version, err)
+			}
+		}
+
+		cursor = entry.Cursor
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (s *redisStore) Ping(ctx context.Context) error {
+	if err := s.client.Do(ctx, s.client.B().Ping().Build()).Error(); err != nil {
+		return fmt.Errorf("redis ping failed: %w", err)
+	}
+	return nil
+}
+
+func (s *redisStore) Close() {
+	s.client.Close()
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestStore(t *testing.T) Store {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	store, err := NewRedisStore(Options{Addrs: []string{mr.Addr()}})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(store.Close)
+	return store
+}
+
+func TestSwapVersionFirstRunHasNoPreviousVersionToDelete(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	if err := store.PutAll(ctx, "v1", "DestinyRaceDefinition", map[string]string{"1": "a"}); err != nil {
+		t.Fatalf("PutAll failed: %v", err)
+	}
+	if err := store.SwapVersion(ctx, "v1"); err != nil {
+		t.Fatalf("SwapVersion failed: %v", err)
+	}
+
+	gotVersion, err := store.CurrentVersion(ctx)
+	if err != nil {
+		t.Fatalf("CurrentVersion failed: %v", err)
+	}
+	if gotVersion != "v1" {
+		t.Fatalf("CurrentVersion = %q, want %q", gotVersion, "v1")
+	}
+
+	val, err := store.Get(ctx, "v1", "DestinyRaceDefinition", "1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if val != "a" {
+		t.Fatalf("Get = %q, want %q", val, "a")
+	}
+}
+
+func TestSwapVersionDeletesThePreviousVersionsKeys(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	mustPutAll(t, store, "v1", map[string]string{"1": "a"})
+	mustSwap(t, store, "v1")
+
+	mustPutAll(t, store, "v2", map[string]string{"1": "b"})
+	mustSwap(t, store, "v2")
+
+	oldVal, err := store.Get(ctx, "v1", "DestinyRaceDefinition", "1")
+	if err != nil {
+		t.Fatalf("Get(v1) failed: %v", err)
+	}
+	if oldVal != "" {
+		t.Fatalf("Get(v1) = %q, want deleted (empty)", oldVal)
+	}
+
+	newVal, err := store.Get(ctx, "v2", "DestinyRaceDefinition", "1")
+	if err != nil {
+		t.Fatalf("Get(v2) failed: %v", err)
+	}
+	if newVal != "b" {
+		t.Fatalf("Get(v2) = %q, want %q", newVal, "b")
+	}
+}
+
+func TestFailedRefreshLeavesThePreviousVersionFullyReadable(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	mustPutAll(t, store, "v1", map[string]string{"1": "a"})
+	mustSwap(t, store, "v1")
+
+	// Simulate a refresh that writes the new version's data but fails before
+	// SwapVersion runs (e.g. a crash or a failed PutAll for another definition).
+	mustPutAll(t, store, "v2", map[string]string{"1": "b"})
+
+	gotVersion, err := store.CurrentVersion(ctx)
+	if err != nil {
+		t.Fatalf("CurrentVersion failed: %v", err)
+	}
+	if gotVersion != "v1" {
+		t.Fatalf("CurrentVersion = %q, want %q (the swap never happened)", gotVersion, "v1")
+	}
+
+	val, err := store.Get(ctx, "v1", "DestinyRaceDefinition", "1")
+	if err != nil {
+		t.Fatalf("Get(v1) failed: %v", err)
+	}
+	if val != "a" {
+		t.Fatalf("Get(v1) = %q, want %q", val, "a")
+	}
+}
+
+func mustPutAll(t *testing.T, store Store, version string, entries map[string]string) {
+	t.Helper()
+	if err := store.PutAll(context.Background(), version, "DestinyRaceDefinition", entries); err != nil {
+		t.Fatalf("PutAll(%s) failed: %v", version, err)
+	}
+}
+
+func mustSwap(t *testing.T, store Store, version string) {
+	t.Helper()
+	if err := store.SwapVersion(context.Background(), version); err != nil {
+		t.Fatalf("SwapVersion(%s) failed: %v", version, err)
+	}
+}
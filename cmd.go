@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dvillavicencio/manifest-cache-scrapper/cache"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const defaultServeAddr = ":8080"
+
+// cacheFlags holds the cache.Options CLI surface shared by oneshot and serve,
+// so batch size/TTL/client-side caching are configurable rather than baked
+// into cache.NewRedisStore's defaults.
+type cacheFlags struct {
+	ttl             time.Duration
+	batchSize       int
+	clientSideCache bool
+	cacheTTL        time.Duration
+}
+
+func (f *cacheFlags) register(flags *pflag.FlagSet) {
+	flags.DurationVar(&f.ttl, "cache-ttl", 0, "TTL applied to each cached entity (0 uses cache.Options' default of 24h)")
+	flags.IntVar(&f.batchSize, "cache-batch-size", 0, "entities per pipelined Redis write (0 uses cache.Options' default of 500)")
+	flags.BoolVar(&f.clientSideCache, "cache-client-side", false, "enable rueidis client-side caching (DoCache) for reads")
+	flags.DurationVar(&f.cacheTTL, "cache-client-side-ttl", 0, "how long a client-side cached read may be served locally")
+}
+
+func (f *cacheFlags) options() cache.Options {
+	return cache.Options{
+		Addrs:           []string{redisAddr},
+		TTL:             f.ttl,
+		BatchSize:       f.batchSize,
+		ClientSideCache: f.clientSideCache,
+		CacheTTL:        f.cacheTTL,
+	}
+}
+
+/**
+* newRootCmd wires up the two ways to run the scrapper: "oneshot" (today's
+* CLI behavior) and "serve" (a long-running service for k8s deployment).
+*/
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "manifest-cache-scrapper",
+		Short: "Fetches the Destiny 2 manifest and caches it in Redis",
+	}
+	root.AddCommand(newOneshotCmd(), newServeCmd())
+	return root
+}
+
+func newOneshotCmd() *cobra.Command {
+	var filterConfigPath string
+	var cacheOpts cacheFlags
+
+	cmd := &cobra.Command{
+		Use:   "oneshot",
+		Short: "Fetch the manifest once, write it to Redis, then exit",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := cache.NewRedisStore(cacheOpts.options())
+			if err != nil {
+				return fmt.Errorf("failed to create Redis store: %w", err)
+			}
+			defer store.Close()
+
+			activityFilter, err := LoadActivityFilterConfig(filterConfigPath)
+			if err != nil {
+				return err
+			}
+
+			return runOneshot(cmd.Context(), store, DefaultRegistry(activityFilter))
+		},
+	}
+	cmd.Flags().StringVar(&filterConfigPath, "activity-filter-config", "", "path to a YAML FilterConfig for DestinyActivityDefinition (defaults to Strike-only)")
+	cacheOpts.register(cmd.Flags())
+	return cmd
+}
+
+func newServeCmd() *cobra.Command {
+	var addr string
+	var filterConfigPath string
+	var refreshSchedule string
+	var cacheOpts cacheFlags
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run as a long-lived service with a periodic refresh and an HTTP API",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := cache.NewRedisStore(cacheOpts.options())
+			if err != nil {
+				return fmt.Errorf("failed to create Redis store: %w", err)
+			}
+			defer store.Close()
+
+			activityFilter, err := LoadActivityFilterConfig(filterConfigPath)
+			if err != nil {
+				return err
+			}
+
+			server := NewServer(store, DefaultRegistry(activityFilter))
+			server.RefreshSchedule = refreshSchedule
+			log.Printf("Listening on %s", addr)
+			return server.Serve(cmd.Context(), addr)
+		},
+	}
+	cmd.Flags().StringVar(&addr, "addr", defaultServeAddr, "address to listen on")
+	cmd.Flags().StringVar(&filterConfigPath, "activity-filter-config", "", "path to a YAML FilterConfig for DestinyActivityDefinition (defaults to Strike-only)")
+	cmd.Flags().StringVar(&refreshSchedule, "refresh-schedule", DefaultRefreshSchedule, "cron schedule for the periodic manifest refresh")
+	cacheOpts.register(cmd.Flags())
+	return cmd
+}
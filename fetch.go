@@ -0,0 +1,140 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"golang.org/x/sync/semaphore"
+)
+
+const (
+	// fetchConcurrency bounds how many Bungie requests are in flight at once.
+	fetchConcurrency = 4
+	requestTimeout    = 30 * time.Second
+	maxRetryElapsed   = 2 * time.Minute
+	// maxResponseBytes guards fetchWithRetry; streamManifestEntities enforces
+	// its own, larger limit (maxEntityBytes) on each definition response
+	// instead of buffering the whole body.
+	maxResponseBytes = 16 * 1024 * 1024
+)
+
+var httpClient = &http.Client{
+	Timeout: requestTimeout,
+}
+
+var fetchSemaphore = semaphore.NewWeighted(fetchConcurrency)
+
+/**
+* Acquires a fetch slot and performs a GET against url, retrying 5xx/429
+* responses with exponential backoff (honoring Retry-After when present) and
+* aborting immediately if ctx is cancelled mid-flight. On success the caller
+* owns resp.Body and must call release() once it's done reading/closing it.
+*/
+func fetchResponse(ctx context.Context, url string) (resp *http.Response, release func(), err error) {
+	if err := fetchSemaphore.Acquire(ctx, 1); err != nil {
+		return nil, func() {}, fmt.Errorf("failed to acquire fetch slot: %w", err)
+	}
+	release = func() { fetchSemaphore.Release(1) }
+
+	operation := func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return backoff.Permanent(fmt.Errorf("failed to build request: %w", err))
+		}
+
+		r, err := httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to call %s: %w", url, err)
+		}
+
+		if r.StatusCode == http.StatusTooManyRequests || r.StatusCode >= http.StatusInternalServerError {
+			wait, ok := retryAfter(r.Header.Get("Retry-After"))
+			r.Body.Close()
+			if ok {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					return backoff.Permanent(ctx.Err())
+				}
+			}
+			return fmt.Errorf("received retryable status %d from %s", r.StatusCode, url)
+		}
+
+		if r.StatusCode != http.StatusOK {
+			r.Body.Close()
+			return backoff.Permanent(fmt.Errorf("received status %d from %s", r.StatusCode, url))
+		}
+
+		resp = r
+		return nil
+	}
+
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = maxRetryElapsed
+	if err := backoff.Retry(operation, backoff.WithContext(expBackoff, ctx)); err != nil {
+		release()
+		return nil, func() {}, err
+	}
+
+	return resp, release, nil
+}
+
+/**
+* Fetches url in full, enforcing maxResponseBytes. Used for the small
+* top-level manifest document; large per-definition payloads stream through
+* streamManifestEntities instead.
+*/
+func fetchWithRetry(ctx context.Context, url string) ([]byte, error) {
+	resp, release, err := fetchResponse(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	defer resp.Body.Close()
+
+	reader, err := decodingBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(reader, maxResponseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", url, err)
+	}
+	if int64(len(body)) > maxResponseBytes {
+		return nil, fmt.Errorf("response from %s exceeded %d bytes", url, maxResponseBytes)
+	}
+	return body, nil
+}
+
+// decodingBody wraps resp.Body in a gzip.Reader when Bungie advertises a
+// gzip-encoded response; otherwise it's returned unwrapped.
+func decodingBody(resp *http.Response) (io.Reader, error) {
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		return resp.Body, nil
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	return gz, nil
+}
+
+func retryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return time.Until(at), true
+	}
+	return 0, false
+}
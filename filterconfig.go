@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+/**
+* FilterConfig describes which DestinyActivityDefinition entries survive
+* filtering. An empty/zero field means "don't filter on this", so the zero
+* value would keep everything; DefaultActivityFilterConfig restores today's
+* Strike-only (mode 4) behavior.
+*/
+type FilterConfig struct {
+	AllowedModes       []int   `yaml:"allowedModes"`
+	ActivityTypeHashes []int64 `yaml:"activityTypeHashes"`
+	RequirePGCRImage   bool    `yaml:"requirePgcrImage"`
+	IsPvP              *bool   `yaml:"isPvP"`
+}
+
+// activityFilterModesEnv overrides AllowedModes with a comma-separated list,
+// e.g. ACTIVITY_FILTER_MODES=4,7,82 to keep Strikes, Raids and Dungeons.
+const activityFilterModesEnv = "ACTIVITY_FILTER_MODES"
+
+// DefaultActivityFilterConfig preserves the scrapper's historical behavior of
+// keeping only mode 4 (Strike) activities.
+func DefaultActivityFilterConfig() FilterConfig {
+	return FilterConfig{AllowedModes: []int{4}}
+}
+
+/**
+* LoadActivityFilterConfig starts from DefaultActivityFilterConfig, overlays a
+* YAML file at path (if non-empty) and then env var overrides, so operators
+* can opt into e.g. Raids+Dungeons+Crucible without a code change.
+*/
+func LoadActivityFilterConfig(path string) (FilterConfig, error) {
+	cfg := DefaultActivityFilterConfig()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return FilterConfig{}, fmt.Errorf("failed to read filter config %s: %w", path, err)
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return FilterConfig{}, fmt.Errorf("failed to parse filter config %s: %w", path, err)
+		}
+	}
+
+	if raw := os.Getenv(activityFilterModesEnv); raw != "" {
+		modes, err := parseIntList(raw)
+		if err != nil {
+			return FilterConfig{}, fmt.Errorf("failed to parse %s: %w", activityFilterModesEnv, err)
+		}
+		cfg.AllowedModes = modes
+	}
+
+	return cfg, nil
+}
+
+func parseIntList(raw string) ([]int, error) {
+	parts := strings.Split(raw, ",")
+	out := make([]int, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid mode %q: %w", part, err)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// ToFilter builds the EntityFilter this config describes.
+func (c FilterConfig) ToFilter() EntityFilter {
+	allowedModes := toSet(c.AllowedModes)
+	activityTypeHashes := toSet(c.ActivityTypeHashes)
+
+	return func(hash string, raw json.RawMessage) bool {
+		var obj ManifestObject
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return false
+		}
+
+		if len(allowedModes) > 0 && !allowedModes[obj.Mode] {
+			return false
+		}
+		if len(activityTypeHashes) > 0 && !activityTypeHashes[obj.ActivityTypeHash] {
+			return false
+		}
+		if c.RequirePGCRImage && obj.PgcrImage == "" {
+			return false
+		}
+		if c.IsPvP != nil && obj.IsPvP != *c.IsPvP {
+			return false
+		}
+		return true
+	}
+}
+
+func toSet[T comparable](values []T) map[T]bool {
+	set := make(map[T]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func rawActivity(t *testing.T, obj ManifestObject) json.RawMessage {
+	t.Helper()
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("failed to marshal test fixture: %v", err)
+	}
+	return raw
+}
+
+func TestFilterConfigToFilter(t *testing.T) {
+	isPvPTrue := true
+	isPvPFalse := false
+
+	strike := ManifestObject{Mode: 4, ActivityTypeHash: 100}
+	raid := ManifestObject{Mode: 4, ActivityTypeHash: 200, PgcrImage: "raid.jpg", IsPvP: false}
+	crucible := ManifestObject{Mode: 5, ActivityTypeHash: 300, PgcrImage: "crucible.jpg", IsPvP: true}
+
+	cases := []struct {
+		name   string
+		cfg    FilterConfig
+		entity ManifestObject
+		want   bool
+	}{
+		{"default strike-only keeps mode 4", DefaultActivityFilterConfig(), strike, true},
+		{"default strike-only drops other modes", DefaultActivityFilterConfig(), crucible, false},
+		{"empty config keeps everything", FilterConfig{}, crucible, true},
+		{"allowed modes keeps matching activityTypeHash", FilterConfig{AllowedModes: []int{4}, ActivityTypeHashes: []int64{200}}, raid, true},
+		{"allowed modes drops non-matching activityTypeHash", FilterConfig{AllowedModes: []int{4}, ActivityTypeHashes: []int64{999}}, raid, false},
+		{"requires PGCR image drops entities without one", FilterConfig{RequirePGCRImage: true}, strike, false},
+		{"requires PGCR image keeps entities with one", FilterConfig{RequirePGCRImage: true}, raid, true},
+		{"isPvP true drops PvE", FilterConfig{IsPvP: &isPvPTrue}, raid, false},
+		{"isPvP true keeps PvP", FilterConfig{IsPvP: &isPvPTrue}, crucible, true},
+		{"isPvP false keeps PvE", FilterConfig{IsPvP: &isPvPFalse}, raid, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter := tc.cfg.ToFilter()
+			if got := filter("hash", rawActivity(t, tc.entity)); got != tc.want {
+				t.Errorf("filter(%+v) = %v, want %v", tc.entity, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFilterConfigToFilterDropsUnparsableEntities(t *testing.T) {
+	filter := FilterConfig{}.ToFilter()
+	if filter("hash", json.RawMessage(`not json`)) {
+		t.Error("expected unparsable entity to be dropped")
+	}
+}
+
+func TestParseIntList(t *testing.T) {
+	cases := []struct {
+		name    string
+		raw     string
+		want    []int
+		wantErr bool
+	}{
+		{"single value", "4", []int{4}, false},
+		{"multiple values", "4,7,82", []int{4, 7, 82}, false},
+		{"trims whitespace", " 4 , 7 ", []int{4, 7}, false},
+		{"rejects non-integer", "4,abc", nil, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseIntList(tc.raw)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("got %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadActivityFilterConfigEnvOverride(t *testing.T) {
+	t.Setenv(activityFilterModesEnv, "6,46")
+
+	cfg, err := LoadActivityFilterConfig("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.AllowedModes) != 2 || cfg.AllowedModes[0] != 6 || cfg.AllowedModes[1] != 46 {
+		t.Fatalf("got AllowedModes %v, want [6 46]", cfg.AllowedModes)
+	}
+}
+
+func TestLoadActivityFilterConfigDefaultsWithNoPathOrEnv(t *testing.T) {
+	cfg, err := LoadActivityFilterConfig("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.AllowedModes) != 1 || cfg.AllowedModes[0] != 4 {
+		t.Fatalf("got AllowedModes %v, want [4]", cfg.AllowedModes)
+	}
+}
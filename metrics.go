@@ -0,0 +1,29 @@
+package main
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	fetchBytesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "manifest_scrapper_fetch_bytes_total",
+		Help: "Total bytes read from Bungie, per definition.",
+	}, []string{"definition"})
+
+	fetchDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "manifest_scrapper_fetch_duration_seconds",
+		Help: "Time spent fetching and writing a definition to Redis.",
+	}, []string{"definition"})
+
+	redisWritesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "manifest_scrapper_redis_writes_total",
+		Help: "Entities written to Redis, per definition.",
+	}, []string{"definition"})
+
+	filterDropsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "manifest_scrapper_filter_drops_total",
+		Help: "Entities dropped by a definition's filter, per definition.",
+	}, []string{"definition"})
+)
+
+func init() {
+	prometheus.MustRegister(fetchBytesTotal, fetchDurationSeconds, redisWritesTotal, filterDropsTotal)
+}
@@ -0,0 +1,55 @@
+package main
+
+import "encoding/json"
+
+// EntityFilter decides whether a raw manifest entity should be kept. Returning
+// false drops the hash from the definition entirely before it reaches Redis.
+type EntityFilter func(hash string, raw json.RawMessage) bool
+
+// EntityProjection narrows a raw manifest entity before it's persisted, e.g.
+// to store only the fields a downstream consumer actually needs.
+type EntityProjection func(raw json.RawMessage) (json.RawMessage, error)
+
+// Definition describes one registered JSONWorldComponentContentPaths entry.
+type Definition struct {
+	// Name must match a key in Manifest.Response.JSONWorldComponentContentPaths.En.
+	Name    string
+	Filter  EntityFilter
+	Project EntityProjection
+}
+
+/**
+* DefinitionRegistry holds the set of manifest definitions main fetches and
+* persists, so new definition types can be added without touching the core
+* fetch/filter/save loop.
+*/
+type DefinitionRegistry struct {
+	definitions []Definition
+}
+
+func NewDefinitionRegistry() *DefinitionRegistry {
+	return &DefinitionRegistry{}
+}
+
+// Register adds a definition to the registry. Filter and Project are optional.
+func (r *DefinitionRegistry) Register(def Definition) {
+	r.definitions = append(r.definitions, def)
+}
+
+func (r *DefinitionRegistry) Definitions() []Definition {
+	return r.definitions
+}
+
+// DefaultRegistry registers the four definitions the scrapper has always
+// fetched, applying activityFilter to DestinyActivityDefinition.
+func DefaultRegistry(activityFilter FilterConfig) *DefinitionRegistry {
+	registry := NewDefinitionRegistry()
+	registry.Register(Definition{Name: "DestinyRaceDefinition"})
+	registry.Register(Definition{Name: "DestinyClassDefinition"})
+	registry.Register(Definition{Name: "DestinyGenderDefinition"})
+	registry.Register(Definition{
+		Name:   "DestinyActivityDefinition",
+		Filter: activityFilter.ToFilter(),
+	})
+	return registry
+}
@@ -4,32 +4,36 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"github.com/redis/go-redis/v9"
+	"os"
+	"os/signal"
+
+	"github.com/dvillavicencio/manifest-cache-scrapper/cache"
+	"golang.org/x/sync/errgroup"
 )
 
 const baseUrl = "https://www.bungie.net"
 const manifestPath = "/Platform/Destiny2/Manifest"
+const redisAddr = "localhost:6379"
 
 type Manifest struct {
 	Response struct {
+		Version                        string `json:"version"`
 		JSONWorldComponentContentPaths struct {
-			En struct {
-				DestinyActivityDefinition string `json:"DestinyActivityDefinition"`
-				DestinyClassDefinition    string `json:"DestinyClassDefinition"`
-				DestinyGenderDefinition   string `json:"DestinyGenderDefinition"`
-				DestinyRaceDefinition     string `json:"DestinyRaceDefinition"`
-			} `json:"en"`
+			// En maps a definition name (e.g. "DestinyActivityDefinition") to
+			// its content path. Bungie exposes ~40 of these; we no longer
+			// hardcode which ones exist so the DefinitionRegistry can drive any of them.
+			En map[string]string `json:"en"`
 		} `json:"jsonWorldComponentContentPaths"`
 	} `json:"Response"`
 }
 
-type ManifestResponse map[string]ManifestObject
-
 type ManifestObject struct {
-	Mode                      int              `json:"directActivityModeType"` // Using *int to handle nil values
+	Mode                      int               `json:"directActivityModeType"` // A missing or null value decodes as 0
+	ActivityTypeHash          int64             `json:"activityTypeHash"`
+	PgcrImage                 string            `json:"pgcrImage"`
+	IsPvP                     bool              `json:"isPvP"`
+	Matchmaking               Matchmaking       `json:"matchmaking"`
 	DisplayProperties         DisplayProperties `json:"displayProperties"`
 	OriginalDisplayProperties DisplayProperties `json:"originalDisplayProperties"`
 	ReleaseIcon               string            `json:"releaseIcon"`
@@ -37,6 +41,10 @@ type ManifestObject struct {
 	// Other fields omitted for brevity
 }
 
+type Matchmaking struct {
+	MaxParty int `json:"maxParty"`
+}
+
 type DisplayProperties struct {
 	Description string `json:"description"`
 	Name        string `json:"name"`
@@ -47,154 +55,78 @@ type DisplayProperties struct {
 /**
 * Fetches the latest manifest
 */
-func fetchManifest(url string) (Manifest, error) {
-  resp, err := http.Get(url)
+func fetchManifest(ctx context.Context, url string) (Manifest, error) {
+  body, err := fetchWithRetry(ctx, url)
   if err != nil {
-    log.Fatalf("Failed to call the Bnet manifest: %v", err)
-    return Manifest{}, fmt.Errorf("Failed to call the Bnet manifest: %v", err)
-  }
-
-  defer resp.Body.Close()
-
-  body, err := io.ReadAll(resp.Body)
-  if err != nil {
-    log.Fatalf("Failed to read body from Bnet response: %v", err)
+    return Manifest{}, fmt.Errorf("failed to call the Bnet manifest: %w", err)
   }
 
   var manifest Manifest
   if err := json.Unmarshal(body, &manifest); err != nil {
-    log.Fatalf("Failed to unmarshal JSON while fetching manifest: %v", err)
-    return Manifest{}, fmt.Errorf("Failed to unmarshal JSON while fetching manifest: %v", err)
+    return Manifest{}, fmt.Errorf("failed to unmarshal JSON while fetching manifest: %w", err)
   }
 
   return manifest, nil
 }
 
 /**
-* Fetches all the corresponding manifest entities
+* Fetches, filters/projects and streams every registered definition straight
+* into Redis concurrently. Concurrency is capped by fetchSemaphore; if ctx is
+* cancelled (e.g. SIGINT), the errgroup aborts the remaining in-flight fetches.
 */
-func fetchManifestEntities(url string) (ManifestResponse, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	var manifest ManifestResponse
-	if err := json.Unmarshal(body, &manifest); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
-	}
-  return manifest, nil
-}
+func runRegistry(ctx context.Context, store cache.Store, manifest Manifest, version string, registry *DefinitionRegistry) error {
+  paths := make(map[string]string, len(registry.Definitions()))
+  for _, def := range registry.Definitions() {
+    path, ok := manifest.Response.JSONWorldComponentContentPaths.En[def.Name]
+    if !ok {
+      return fmt.Errorf("manifest has no content path registered for %s", def.Name)
+    }
+    paths[def.Name] = path
+  }
 
-/**
-* Filter out activities based on the mode
-*/
-func filterActivities(manifestResponse ManifestResponse) ManifestResponse {
-	// Filtering out manifest objects
-  log.Printf("Size of response before filtering: %d", len(manifestResponse))
-  filteredManifest := make(ManifestResponse)
-
-	for hash, data := range manifestResponse {
-		if data.Mode != 4 {
-			// Skip if mode is nil or mode is not equal to 4
-			continue
-		}
-		filteredManifest[hash] = data
-	}
-
-  log.Printf("Size of response after filtering: %d", len(filteredManifest))
-	return filteredManifest
-}
+  group, ctx := errgroup.WithContext(ctx)
 
-func clearCache(ctx context.Context, client *redis.Client) error {
-  
-  result, err := client.FlushAll(ctx).Result();
-  if err != nil {
-    return fmt.Errorf("Failed to flush the Redis cache: %w", err)
+  for _, def := range registry.Definitions() {
+    def := def
+    path := paths[def.Name]
+
+    group.Go(func() error {
+      return streamDefinitionToStore(ctx, store, version, def, baseUrl+path)
+    })
   }
-  log.Printf("Redis cache cleared: %s", result)
-  return nil;
+
+  return group.Wait()
 }
 
 /**
-* Save the data to Redis
+* Fetches the manifest once, refreshes every registered definition and swaps
+* the version pointer. This is what "oneshot" runs, and what "serve" triggers
+* on its cron schedule and from /refresh.
 */
-func saveToRedis(ctx context.Context, client *redis.Client, data ManifestResponse) error {
-  log.Printf("Saving %d items to Redis", len(data))
-  for key, value := range data {
-
-    jsonValue, err := json.Marshal(value)
-    log.Printf("Saving hash [%s] with value [%v] to Redis...", key, value)
-    if err != nil {
-      return fmt.Errorf("Failed to serialize data to JSON for key [%s] and value [%v]. Error: %v", key, value, err)
-    }
-
-    client.Set(ctx, key, jsonValue, 0)
+func runOneshot(ctx context.Context, store cache.Store, registry *DefinitionRegistry) error {
+  manifest, err := fetchManifest(ctx, baseUrl+manifestPath)
+  if err != nil {
+    return err
   }
-  log.Printf("Finished saving all items to Redis!")
-  return nil
-}
+  version := manifest.Response.Version
 
-func flattenMaps(responses ...ManifestResponse) ManifestResponse {
-    result := make(ManifestResponse)
-
-    for _, m := range responses {
-        for key, value := range m {
-            result[key] = value // Overwrites if the key already exists
-        }
-    }
+  if err := runRegistry(ctx, store, manifest, version, registry); err != nil {
+    return fmt.Errorf("error refreshing manifest: %w", err)
+  }
 
-    log.Printf("Size of flattening data is: %d", len(result))
-    return result
+  if err := store.SwapVersion(ctx, version); err != nil {
+    return fmt.Errorf("error swapping manifest version: %w", err)
+  }
+  log.Printf("Finished saving manifest version %s!", version)
+  return nil
 }
 
 func main() {
-  client := redis.NewClient(&redis.Options{
-      Addr: "localhost:6379",
-      Password: "",
-      DB: 0,
-      Protocol: 2,
-    })
-  
-  ctx := context.Background()
-  if err := clearCache(ctx, client); err != nil {
-    fmt.Println("Error clearing cache: ", err)
-    return
-  } 
-
-  manifest, err := fetchManifest(baseUrl + manifestPath)
-  if err != nil {
-    log.Fatalf("%v", err)
-  } 
+  ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+  defer stop()
 
-  raceInfo, err := fetchManifestEntities(baseUrl + manifest.Response.JSONWorldComponentContentPaths.En.DestinyRaceDefinition)
-  if err != nil {
-    log.Fatalf("Error fetching race entities: %v", err)
-    return
-  }
-  classInfo, err := fetchManifestEntities(baseUrl + manifest.Response.JSONWorldComponentContentPaths.En.DestinyClassDefinition)
-  if err != nil {
-    log.Fatalf("Error fetching class entities: %v", err)
-  }
-  genderInfo, err := fetchManifestEntities(baseUrl + manifest.Response.JSONWorldComponentContentPaths.En.DestinyGenderDefinition)
-  if err != nil {
-    log.Fatalf("Error fetching gender entities: %v", err)
-  }
-
-  activityInfo, err := fetchManifestEntities(baseUrl + manifest.Response.JSONWorldComponentContentPaths.En.DestinyActivityDefinition)
-  if err != nil {
-    log.Fatalf("Error fetching activity entities: %v", err)
+  if err := newRootCmd().ExecuteContext(ctx); err != nil {
+    log.Fatalf("%v", err)
   }
-
-  filteredActivities := filterActivities(activityInfo)
-  data := flattenMaps(raceInfo, classInfo, genderInfo, filteredActivities) 
-  
-  saveToRedis(ctx, client, data)
 }
 
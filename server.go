@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dvillavicencio/manifest-cache-scrapper/cache"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/robfig/cron/v3"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultRefreshSchedule is used when Server.RefreshSchedule is left empty.
+const DefaultRefreshSchedule = "@every 6h"
+
+/**
+* Server keeps the scrapper alive as a long-running process: it refreshes the
+* manifest on a cron schedule and lets operators trigger/inspect refreshes
+* over HTTP instead of re-invoking the binary from an external cron.
+*/
+type Server struct {
+	store    cache.Store
+	registry *DefinitionRegistry
+
+	// RefreshSchedule is a robfig/cron schedule spec (e.g. "@every 6h").
+	// Defaults to DefaultRefreshSchedule when empty.
+	RefreshSchedule string
+
+	group singleflight.Group
+
+	mu          sync.Mutex
+	lastRefresh time.Time
+	lastErr     error
+}
+
+func NewServer(store cache.Store, registry *DefinitionRegistry) *Server {
+	return &Server{store: store, registry: registry}
+}
+
+// refresh runs runOneshot, coalescing concurrent callers (cron tick racing a
+// manual /refresh, or two manual calls) into a single in-flight run.
+func (s *Server) refresh(ctx context.Context) error {
+	_, err, _ := s.group.Do("refresh", func() (interface{}, error) {
+		return nil, runOneshot(ctx, s.store, s.registry)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = err
+	if err == nil {
+		s.lastRefresh = time.Now()
+	}
+	return err
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if err := s.refresh(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	pingErr := s.store.Ping(r.Context())
+
+	s.mu.Lock()
+	lastRefresh, lastErr := s.lastRefresh, s.lastErr
+	s.mu.Unlock()
+
+	if pingErr != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "redis unavailable: %v\n", pingErr)
+	} else {
+		fmt.Fprintln(w, "redis ok")
+	}
+
+	switch {
+	case lastRefresh.IsZero():
+		fmt.Fprintln(w, "no refresh has completed yet")
+	case lastErr != nil:
+		fmt.Fprintf(w, "last refresh failed: %v (last successful refresh at %s)\n", lastErr, lastRefresh.Format(time.RFC3339))
+	default:
+		fmt.Fprintf(w, "last successful refresh at %s\n", lastRefresh.Format(time.RFC3339))
+	}
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /refresh", s.handleRefresh)
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.Handle("GET /metrics", promhttp.Handler())
+	return mux
+}
+
+/**
+* Serve runs the cron-scheduled refresh and HTTP API until ctx is cancelled,
+* then drains in-flight requests before returning.
+*/
+func (s *Server) Serve(ctx context.Context, addr string) error {
+	schedule := s.RefreshSchedule
+	if schedule == "" {
+		schedule = DefaultRefreshSchedule
+	}
+
+	scheduler := cron.New()
+	if _, err := scheduler.AddFunc(schedule, func() {
+		if err := s.refresh(ctx); err != nil {
+			log.Printf("scheduled refresh failed: %v", err)
+		}
+	}); err != nil {
+		return fmt.Errorf("failed to schedule refresh: %w", err)
+	}
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	httpServer := &http.Server{Addr: addr, Handler: s.routes()}
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("error shutting down HTTP server: %v", err)
+		}
+	}()
+
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("HTTP server failed: %w", err)
+	}
+	// ListenAndServe returns as soon as Shutdown begins; wait for it to finish.
+	<-shutdownDone
+	return nil
+}
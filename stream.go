@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/dvillavicencio/manifest-cache-scrapper/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// maxEntityBytes guards a single definition response (some, like
+	// DestinyInventoryItemDefinition, exceed 50MB uncompressed).
+	maxEntityBytes = 256 * 1024 * 1024
+	// streamBatchSize caps how many entities are buffered before a PutAll flush.
+	streamBatchSize = 500
+)
+
+type hashedEntity struct {
+	Hash string
+	Raw  json.RawMessage
+}
+
+/**
+* Streams a definition's { hash: entity, ... } response one entry at a time
+* instead of buffering the whole body, so peak memory stays proportional to
+* one entity rather than the entire (sometimes 50MB+) definition.
+*/
+func streamManifestEntities(ctx context.Context, url string, sink func(hash string, raw json.RawMessage) error) error {
+	resp, release, err := fetchResponse(ctx, url)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer release()
+	defer resp.Body.Close()
+
+	reader, err := decodingBody(resp)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(io.LimitReader(reader, maxEntityBytes+1))
+
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("failed to read opening token: %w", err)
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to read hash key: %w", err)
+		}
+		hash, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("expected string hash key, got %v", tok)
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("failed to decode entity %s: %w", hash, err)
+		}
+
+		if err := sink(hash, raw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/**
+* Streams a single definition into Redis: one goroutine decodes entities off
+* the wire and applies the definition's filter/projection, a second batches
+* them into PutAll calls, so the next definition can start downloading while
+* this one is still being written.
+*/
+func streamDefinitionToStore(ctx context.Context, store cache.Store, version string, def Definition, url string) error {
+	timer := prometheus.NewTimer(fetchDurationSeconds.WithLabelValues(def.Name))
+	defer timer.ObserveDuration()
+
+	entities := make(chan hashedEntity, streamBatchSize)
+
+	group, ctx := errgroup.WithContext(ctx)
+
+	group.Go(func() error {
+		defer close(entities)
+		return streamManifestEntities(ctx, url, func(hash string, raw json.RawMessage) error {
+			fetchBytesTotal.WithLabelValues(def.Name).Add(float64(len(hash) + len(raw)))
+
+			if def.Filter != nil && !def.Filter(hash, raw) {
+				filterDropsTotal.WithLabelValues(def.Name).Inc()
+				return nil
+			}
+			if def.Project != nil {
+				projected, err := def.Project(raw)
+				if err != nil {
+					return fmt.Errorf("failed to project %s/%s: %w", def.Name, hash, err)
+				}
+				raw = projected
+			}
+
+			select {
+			case entities <- hashedEntity{Hash: hash, Raw: raw}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	})
+
+	group.Go(func() error {
+		batch := make(map[string]string, streamBatchSize)
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			log.Printf("Saving %d [%s] items to Redis", len(batch), def.Name)
+			if err := store.PutAll(ctx, version, def.Name, batch); err != nil {
+				return err
+			}
+			redisWritesTotal.WithLabelValues(def.Name).Add(float64(len(batch)))
+			batch = make(map[string]string, streamBatchSize)
+			return nil
+		}
+
+		for entity := range entities {
+			batch[entity.Hash] = string(entity.Raw)
+			if len(batch) >= streamBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		return flush()
+	})
+
+	return group.Wait()
+}